@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// SettingHistory is an append-only audit record of a single setting change,
+// letting an admin see who changed what and when, and revert it.
+type SettingHistory struct {
+	Id       int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	Key      string    `json:"key"`
+	OldValue string    `json:"oldValue"`
+	NewValue string    `json:"newValue"`
+	Actor    string    `json:"actor"`
+	SourceIP string    `json:"sourceIp"`
+	At       time.Time `json:"at"`
+}
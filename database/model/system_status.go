@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// SystemStatus is a single periodic sample of system/xray health, written
+// by the collect_status cron job so the dashboard can chart a time range
+// instead of only ever showing the current instant.
+type SystemStatus struct {
+	Id int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	At time.Time `json:"at" gorm:"index"`
+
+	Cpu float64 `json:"cpu"`
+
+	MemCurrent  uint64 `json:"memCurrent"`
+	MemTotal    uint64 `json:"memTotal"`
+	SwapCurrent uint64 `json:"swapCurrent"`
+	SwapTotal   uint64 `json:"swapTotal"`
+	DiskCurrent uint64 `json:"diskCurrent"`
+	DiskTotal   uint64 `json:"diskTotal"`
+
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
+
+	TcpCount int `json:"tcpCount"`
+	UdpCount int `json:"udpCount"`
+
+	NetIOUp        uint64 `json:"netIOUp"`
+	NetIODown      uint64 `json:"netIODown"`
+	NetTrafficSent uint64 `json:"netTrafficSent"`
+	NetTrafficRecv uint64 `json:"netTrafficRecv"`
+
+	XrayUp      bool   `json:"xrayUp"`
+	XrayVersion string `json:"xrayVersion"`
+}
@@ -0,0 +1,92 @@
+// Package cron is a small internal job scheduler: a registry of named,
+// fixed-interval jobs that run until the scheduler is stopped. It exists so
+// features that need periodic background work (status collection today;
+// traffic reset, cert renewal, backups later) all register through one
+// place instead of each spinning up its own ticker goroutine.
+package cron
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"x-ui/logger"
+)
+
+// Job is a single unit of periodic work.
+type Job struct {
+	// Name identifies the job in logs; it must be unique within a
+	// Scheduler.
+	Name string
+	// Interval is the time between runs. It must be positive.
+	Interval time.Duration
+	// Run is invoked on every tick. ctx is cancelled when the scheduler is
+	// stopped, so long-running work should watch it.
+	Run func(ctx context.Context) error
+}
+
+// Scheduler runs a registry of Jobs, each on its own ticker.
+type Scheduler struct {
+	mu     sync.Mutex
+	jobs   map[string]context.CancelFunc
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewScheduler builds a Scheduler. Jobs start running as soon as they are
+// registered; call Stop to shut everything down.
+func NewScheduler() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		jobs:   make(map[string]context.CancelFunc),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Register adds job to the scheduler and starts its ticker right away; the
+// first run happens one Interval later. Registering a job with a name that's
+// already registered replaces it. A job with a non-positive Interval is
+// rejected instead of registered, since time.NewTicker panics on one —
+// a single misconfigured job (e.g. an operator-editable setting set to 0)
+// must not be able to bring down the whole panel.
+func (s *Scheduler) Register(job Job) error {
+	if job.Interval <= 0 {
+		return fmt.Errorf("cron job %q: interval must be positive, got %v", job.Name, job.Interval)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cancel, ok := s.jobs[job.Name]; ok {
+		cancel()
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.jobs[job.Name] = cancel
+	go s.run(ctx, job)
+	return nil
+}
+
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job.Run(ctx); err != nil {
+				logger.Warningf("cron job %q failed: %v", job.Name, err)
+			}
+		}
+	}
+}
+
+// Stop cancels every registered job. The Scheduler cannot be restarted
+// after Stop; build a new one instead.
+func (s *Scheduler) Stop() {
+	s.cancel()
+}
@@ -0,0 +1,58 @@
+// Package httpclient is a thin helper around net/http for outbound calls
+// that must honor a caller's context.Context: a per-request deadline, and
+// prompt cancellation when the panel shuts down or a browser-side request
+// is aborted.
+//
+// Callers own the context's lifetime — wrap it with context.WithTimeout
+// (or WithDeadline) before calling Get/NewRequest if the call needs a
+// tighter bound, and only cancel it once the response body has been
+// fully read and closed.
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// connectTimeout bounds dialing, TLS handshake, and waiting for response
+// headers, so a stalled connection to an unresponsive or dead server
+// (e.g. GitHub during an outage) fails fast instead of hanging forever,
+// even if the caller's context carries no deadline of its own. It does
+// not bound streaming the response body, since a slow-but-progressing
+// download (UpdateXray's release zip) must be allowed to run long.
+const connectTimeout = 15 * time.Second
+
+// Client is the shared http.Client used for every outbound call in the
+// panel. It has no overall request timeout of its own — that's the
+// caller's job via the context — but its Transport enforces
+// connectTimeout so a dead peer can't hang a request indefinitely.
+var Client = &http.Client{
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout: connectTimeout,
+		}).DialContext,
+		TLSHandshakeTimeout:   connectTimeout,
+		ResponseHeaderTimeout: connectTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	},
+}
+
+// NewRequest builds a request bound to ctx, for callers that need to set
+// custom headers before sending it via Client.Do.
+func NewRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, method, url, body)
+}
+
+// Get issues a GET request bound to ctx: the request is aborted as soon as
+// ctx is done, whether that's a deadline or an explicit cancel.
+func Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := NewRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return Client.Do(req)
+}
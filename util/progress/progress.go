@@ -0,0 +1,92 @@
+// Package progress wraps an io.Reader to report how much of it has been
+// read, at what speed, so long-running downloads can drive a live progress
+// bar instead of leaving the caller staring at a hung UI.
+package progress
+
+import (
+	"io"
+	"time"
+)
+
+// Update is a single progress sample.
+type Update struct {
+	Bytes   int64   `json:"bytes"`
+	Total   int64   `json:"total"`
+	Percent float64 `json:"percent"`
+	// BytesPerSecond is measured since the previous Update, not the
+	// lifetime average.
+	BytesPerSecond float64 `json:"bytesPerSecond"`
+}
+
+// minInterval throttles how often OnProgress fires so a fast local link
+// doesn't spam the callback on every read() syscall.
+const minInterval = 200 * time.Millisecond
+
+// Reader wraps an io.Reader, invoking onProgress as bytes are read.
+// onProgress is always called once more after the final byte is read.
+type Reader struct {
+	r          io.Reader
+	total      int64
+	onProgress func(Update)
+
+	read       int64
+	lastReport time.Time
+	lastRead   int64
+}
+
+// NewReader wraps r, reporting progress against total (use 0 if the total
+// size is unknown; Percent will then stay 0).
+func NewReader(r io.Reader, total int64, onProgress func(Update)) *Reader {
+	return NewResumedReader(r, total, 0, onProgress)
+}
+
+// NewResumedReader is like NewReader, but seeds the counters with existing
+// bytes already accounted for (e.g. the part of a download kept from a
+// previous, interrupted attempt), so a resumed transfer reports progress
+// against the whole file instead of restarting from 0%. It reports that
+// seeded state once immediately, before any bytes are read from r.
+func NewResumedReader(r io.Reader, total int64, existing int64, onProgress func(Update)) *Reader {
+	pr := &Reader{
+		r:          r,
+		total:      total,
+		onProgress: onProgress,
+		read:       existing,
+		lastRead:   existing,
+		lastReport: time.Now(),
+	}
+	if existing > 0 {
+		pr.report(pr.lastReport, 0)
+	}
+	return pr
+}
+
+func (pr *Reader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	pr.read += int64(n)
+
+	now := time.Now()
+	elapsed := now.Sub(pr.lastReport)
+	if elapsed >= minInterval || err != nil {
+		pr.report(now, elapsed)
+	}
+	return n, err
+}
+
+func (pr *Reader) report(now time.Time, elapsed time.Duration) {
+	speed := 0.0
+	if elapsed > 0 {
+		speed = float64(pr.read-pr.lastRead) / elapsed.Seconds()
+	}
+	percent := 0.0
+	if pr.total > 0 {
+		percent = float64(pr.read) / float64(pr.total) * 100
+	}
+	pr.onProgress(Update{
+		Bytes:          pr.read,
+		Total:          pr.total,
+		Percent:        percent,
+		BytesPerSecond: speed,
+	})
+	pr.lastReport = now
+	pr.lastRead = pr.read
+}
@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"x-ui/web/metrics"
+	"x-ui/web/service"
+)
+
+// MetricsController mounts a Prometheus scrape endpoint under the panel's
+// base path. It is gated with HTTP basic auth using the panel's existing
+// secret, so metrics aren't exposed to anyone who can merely reach the URL.
+type MetricsController struct {
+	settingService service.SettingService
+}
+
+func NewMetricsController(g *gin.RouterGroup, settingService service.SettingService) *MetricsController {
+	a := &MetricsController{settingService: settingService}
+	a.initRouter(g)
+	return a
+}
+
+func (a *MetricsController) initRouter(g *gin.RouterGroup) {
+	g.GET("/metrics", a.requireSecret, gin.WrapH(metrics.Handler()))
+}
+
+func (a *MetricsController) requireSecret(c *gin.Context) {
+	secret, err := a.settingService.GetSecret()
+	if err != nil || len(secret) == 0 {
+		c.AbortWithStatus(http.StatusServiceUnavailable)
+		return
+	}
+
+	_, password, ok := c.Request.BasicAuth()
+	if !ok || subtle.ConstantTimeCompare([]byte(password), secret) != 1 {
+		c.Header("WWW-Authenticate", `Basic realm="metrics"`)
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"x-ui/web/service"
+)
+
+// ServerController exposes the panel/server maintenance endpoints:
+// Xray version listing and update, and the stored status history query.
+// Version listing and update are backed by outbound HTTP calls, so those
+// handlers pass the request's context through to the service layer to
+// abort promptly on client disconnect or panel shutdown.
+type ServerController struct {
+	serverService service.ServerService
+}
+
+func NewServerController(g *gin.RouterGroup, serverService service.ServerService) *ServerController {
+	a := &ServerController{serverService: serverService}
+	a.initRouter(g)
+	return a
+}
+
+func (a *ServerController) initRouter(g *gin.RouterGroup) {
+	g = g.Group("/server")
+	g.GET("/getXrayVersions", a.getXrayVersions)
+	g.POST("/updateXray/:version", a.updateXray)
+	g.GET("/status", a.queryStatus)
+}
+
+func (a *ServerController) getXrayVersions(c *gin.Context) {
+	versions, err := a.serverService.GetXrayVersions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "obj": versions})
+}
+
+func (a *ServerController) updateXray(c *gin.Context) {
+	version := c.Param("version")
+	err := a.serverService.UpdateXray(c.Request.Context(), version, nil)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// queryStatus feeds the dashboard's time-range chart: ?from=<RFC3339>&to=<RFC3339>&step=<duration>.
+func (a *ServerController) queryStatus(c *gin.Context) {
+	from, err := time.Parse(time.RFC3339, c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "msg": "invalid from"})
+		return
+	}
+	to, err := time.Parse(time.RFC3339, c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "msg": "invalid to"})
+		return
+	}
+	step, err := time.ParseDuration(c.DefaultQuery("step", "0s"))
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "msg": "invalid step"})
+		return
+	}
+
+	series, err := a.serverService.QueryStatus(from, to, step)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "obj": series})
+}
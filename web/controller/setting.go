@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"x-ui/web/entity"
+	"x-ui/web/service"
+)
+
+// SettingController exposes the panel settings, including the setting
+// change audit log and rollback.
+type SettingController struct {
+	settingService service.SettingService
+}
+
+func NewSettingController(g *gin.RouterGroup, settingService service.SettingService) *SettingController {
+	a := &SettingController{settingService: settingService}
+	a.initRouter(g)
+	return a
+}
+
+func (a *SettingController) initRouter(g *gin.RouterGroup) {
+	g = g.Group("/setting")
+	g.GET("/all", a.getAllSetting)
+	g.POST("/update", a.updateAllSetting)
+	g.POST("/reset", a.resetSettings)
+	g.GET("/history", a.listHistory)
+	g.POST("/rollback/:id", a.rollback)
+}
+
+// actorFrom builds an Actor from the current request: the "username" value
+// of the gin context and the client's source IP. No middleware mounted by
+// NewPanel sets "username", so the name is empty there and the change is
+// recorded as "system".
+func actorFrom(c *gin.Context) service.Actor {
+	username, _ := c.Get("username")
+	name, _ := username.(string)
+	return service.Actor{Name: name, SourceIP: c.ClientIP()}
+}
+
+func (a *SettingController) getAllSetting(c *gin.Context) {
+	allSetting, err := a.settingService.GetAllSetting()
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "obj": allSetting})
+}
+
+func (a *SettingController) updateAllSetting(c *gin.Context) {
+	allSetting := &entity.AllSetting{}
+	if err := c.ShouldBind(allSetting); err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "msg": err.Error()})
+		return
+	}
+	if err := a.settingService.UpdateAllSetting(allSetting, actorFrom(c)); err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+func (a *SettingController) resetSettings(c *gin.Context) {
+	if err := a.settingService.ResetSettings(actorFrom(c)); err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+func (a *SettingController) listHistory(c *gin.Context) {
+	key := c.Query("key")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	history, err := a.settingService.ListHistory(key, limit)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "obj": history})
+}
+
+func (a *SettingController) rollback(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "msg": "invalid history id"})
+		return
+	}
+	if err := a.settingService.Rollback(id, actorFrom(c)); err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
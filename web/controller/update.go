@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"x-ui/logger"
+	"x-ui/util/progress"
+	"x-ui/web/service"
+)
+
+var updateUpgrader = websocket.Upgrader{
+	// The panel is same-origin by design; there's no third-party page that
+	// should be allowed to open this socket. CheckOrigin is left nil, so the
+	// upgrader's default check rejects a handshake whose Origin host differs
+	// from the request's Host.
+}
+
+// UpdateController drives "Update Xray" over a WebSocket: the client sends
+// the target version once connected, and the server streams back progress
+// updates until the update finishes or fails.
+type UpdateController struct {
+	serverService service.ServerService
+}
+
+func NewUpdateController(g *gin.RouterGroup, serverService service.ServerService) *UpdateController {
+	a := &UpdateController{serverService: serverService}
+	a.initRouter(g)
+	return a
+}
+
+func (a *UpdateController) initRouter(g *gin.RouterGroup) {
+	g.GET("/server/updateXrayWs", a.updateXrayWs)
+}
+
+type updateRequest struct {
+	Version string `json:"version"`
+}
+
+type updateMessage struct {
+	Type     string           `json:"type"` // "progress", "error" or "done"
+	Progress *progress.Update `json:"progress,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
+func (a *UpdateController) updateXrayWs(c *gin.Context) {
+	conn, err := updateUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logger.Warning("failed to upgrade update websocket:", err)
+		return
+	}
+	defer conn.Close()
+
+	var req updateRequest
+	if err := conn.ReadJSON(&req); err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	// Abort the update as soon as the browser closes the socket.
+	go func() {
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	err = a.serverService.UpdateXray(ctx, req.Version, func(u progress.Update) {
+		conn.WriteJSON(updateMessage{Type: "progress", Progress: &u})
+	})
+	if err != nil {
+		conn.WriteJSON(updateMessage{Type: "error", Error: err.Error()})
+		return
+	}
+	conn.WriteJSON(updateMessage{Type: "done"})
+}
@@ -0,0 +1,50 @@
+// Package entity holds the request/response shapes for the web API that
+// don't belong to a single controller.
+package entity
+
+import "x-ui/util/common"
+
+// AllSetting is the full set of panel settings as exposed through the
+// settings REST API. SettingService.GetAllSetting/UpdateAllSetting reflect
+// over this struct's fields by their json tag to match them up with rows in
+// the settings table, so every key in SettingService's defaultValueMap
+// needs a field here to be readable or writable from the frontend.
+type AllSetting struct {
+	XrayTemplateConfig string `json:"xrayTemplateConfig"`
+	WebListen          string `json:"webListen"`
+	WebPort            int    `json:"webPort"`
+	WebCertFile        string `json:"webCertFile"`
+	WebKeyFile         string `json:"webKeyFile"`
+	Secret             string `json:"secret"`
+	WebBasePath        string `json:"webBasePath"`
+	TimeLocation       string `json:"timeLocation"`
+
+	// NotifyEnabled is "true"/"false" rather than bool: the reflection in
+	// GetAllSetting/UpdateAllSetting only understands int and string
+	// fields, matching how every setting is stored as a string column.
+	NotifyEnabled             string `json:"notifyEnabled"`
+	NotifyBackend             string `json:"notifyBackend"`
+	NotifyURL                 string `json:"notifyURL"`
+	NotifyToken               string `json:"notifyToken"`
+	NotifyTopics              string `json:"notifyTopics"`
+	NotifyFirestoreProject    string `json:"notifyFirestoreProject"`
+	NotifyFirestoreCollection string `json:"notifyFirestoreCollection"`
+
+	StatusCollectInterval int `json:"statusCollectInterval"`
+	StatusRetentionHours  int `json:"statusRetentionHours"`
+}
+
+// CheckValid sanity-checks settings an operator is about to save, before
+// they're persisted and take effect.
+func (s *AllSetting) CheckValid() error {
+	if s.WebPort <= 0 || s.WebPort > 65535 {
+		return common.NewErrorf("web port <%v> is out of range", s.WebPort)
+	}
+	if s.StatusCollectInterval <= 0 {
+		return common.NewErrorf("status collect interval <%v> must be positive", s.StatusCollectInterval)
+	}
+	if s.StatusRetentionHours <= 0 {
+		return common.NewErrorf("status retention hours <%v> must be positive", s.StatusRetentionHours)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+// Package metrics exposes the panel's system status as a standard
+// Prometheus scrape target, so operators can point Grafana (or anything
+// else that speaks the Prometheus exposition format) at the panel instead
+// of relying on an ad-hoc push channel.
+package metrics
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	cpuPercent = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "xui_cpu_percent",
+		Help: "Current CPU usage percentage.",
+	})
+	memCurrent = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "xui_mem_used_bytes",
+		Help: "Memory currently in use, in bytes.",
+	})
+	memTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "xui_mem_total_bytes",
+		Help: "Total memory, in bytes.",
+	})
+	swapCurrent = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "xui_swap_used_bytes",
+		Help: "Swap currently in use, in bytes.",
+	})
+	swapTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "xui_swap_total_bytes",
+		Help: "Total swap, in bytes.",
+	})
+	diskCurrent = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "xui_disk_used_bytes",
+		Help: "Disk space currently in use, in bytes.",
+	})
+	diskTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "xui_disk_total_bytes",
+		Help: "Total disk space, in bytes.",
+	})
+	load1 = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "xui_load1",
+		Help: "1-minute load average.",
+	})
+	load5 = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "xui_load5",
+		Help: "5-minute load average.",
+	})
+	load15 = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "xui_load15",
+		Help: "15-minute load average.",
+	})
+	tcpCount = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "xui_tcp_connections",
+		Help: "Current number of TCP connections.",
+	})
+	udpCount = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "xui_udp_connections",
+		Help: "Current number of UDP connections.",
+	})
+	netIOUp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "xui_net_io_up_bytes_per_second",
+		Help: "Outbound network throughput, in bytes per second.",
+	})
+	netIODown = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "xui_net_io_down_bytes_per_second",
+		Help: "Inbound network throughput, in bytes per second.",
+	})
+	netTrafficSent = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "xui_net_traffic_sent_bytes_total",
+		Help: "Bytes sent since the panel's first status sample.",
+	})
+	netTrafficRecv = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "xui_net_traffic_recv_bytes_total",
+		Help: "Bytes received since the panel's first status sample.",
+	})
+	xrayUp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "xui_xray_up",
+		Help: "Whether Xray is currently running (1) or not (0).",
+	})
+	xrayInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "xui_xray_info",
+		Help: "Xray version, exposed as a label on a constant gauge.",
+	}, []string{"version"})
+
+	// lastMu guards the "last observed" state below, since Observe can be
+	// called concurrently: once on the cron collector's ticker goroutine
+	// and once from an on-demand dashboard request.
+	lastMu sync.Mutex
+	// lastTrafficSent/lastTrafficRecv track the previous cumulative reading
+	// so repeated Observe calls can Add the delta to the counters above.
+	lastTrafficSent uint64
+	lastTrafficRecv uint64
+	lastXrayVersion string
+)
+
+func init() {
+	prometheus.MustRegister(
+		cpuPercent, memCurrent, memTotal, swapCurrent, swapTotal,
+		diskCurrent, diskTotal, load1, load5, load15,
+		tcpCount, udpCount, netIOUp, netIODown,
+		netTrafficSent, netTrafficRecv, xrayUp, xrayInfo,
+	)
+}
+
+// Sample is the subset of a system status snapshot that this package turns
+// into Prometheus metrics. It's defined independently of web/service.Status
+// to keep this package free of a dependency on the service layer.
+type Sample struct {
+	Cpu float64
+
+	MemCurrent, MemTotal   uint64
+	SwapCurrent, SwapTotal uint64
+	DiskCurrent, DiskTotal uint64
+
+	Loads []float64
+
+	TcpCount, UdpCount int
+	NetIOUp, NetIODown uint64
+	NetTrafficSent     uint64
+	NetTrafficRecv     uint64
+
+	XrayUp      bool
+	XrayVersion string
+}
+
+// Observe updates every registered metric from a single status sample.
+func Observe(s Sample) {
+	cpuPercent.Set(s.Cpu)
+	memCurrent.Set(float64(s.MemCurrent))
+	memTotal.Set(float64(s.MemTotal))
+	swapCurrent.Set(float64(s.SwapCurrent))
+	swapTotal.Set(float64(s.SwapTotal))
+	diskCurrent.Set(float64(s.DiskCurrent))
+	diskTotal.Set(float64(s.DiskTotal))
+	if len(s.Loads) >= 3 {
+		load1.Set(s.Loads[0])
+		load5.Set(s.Loads[1])
+		load15.Set(s.Loads[2])
+	}
+	tcpCount.Set(float64(s.TcpCount))
+	udpCount.Set(float64(s.UdpCount))
+	netIOUp.Set(float64(s.NetIOUp))
+	netIODown.Set(float64(s.NetIODown))
+
+	lastMu.Lock()
+	if lastTrafficSent != 0 && s.NetTrafficSent >= lastTrafficSent {
+		netTrafficSent.Add(float64(s.NetTrafficSent - lastTrafficSent))
+	}
+	if lastTrafficRecv != 0 && s.NetTrafficRecv >= lastTrafficRecv {
+		netTrafficRecv.Add(float64(s.NetTrafficRecv - lastTrafficRecv))
+	}
+	lastTrafficSent = s.NetTrafficSent
+	lastTrafficRecv = s.NetTrafficRecv
+
+	if s.XrayVersion != "" && s.XrayVersion != lastXrayVersion {
+		if lastXrayVersion != "" {
+			xrayInfo.WithLabelValues(lastXrayVersion).Set(0)
+		}
+		xrayInfo.WithLabelValues(s.XrayVersion).Set(1)
+		lastXrayVersion = s.XrayVersion
+	}
+	lastMu.Unlock()
+
+	if s.XrayUp {
+		xrayUp.Set(1)
+	} else {
+		xrayUp.Set(0)
+	}
+}
+
+// Handler returns the HTTP handler that serves the Prometheus exposition
+// format. Callers are responsible for mounting it under the configured
+// base path and applying any auth gate.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	"x-ui/logger"
+	"x-ui/util/httpclient"
+)
+
+// FirestoreConfig configures the optional Firestore backend, selected by
+// setting notifyBackend to BackendFirestore. Unlike the previous hardcoded
+// integration, every field must be supplied explicitly by the operator —
+// there is no built-in project or collection.
+type FirestoreConfig struct {
+	ProjectID      string
+	CollectionName string
+	Token          string
+	Timeout        time.Duration
+}
+
+// FirestoreService writes events as documents to a Google Firestore
+// collection. It is opt-in and only usable when an operator configures a
+// project and collection explicitly.
+type FirestoreService struct {
+	baseURL string
+	token   string
+	timeout time.Duration
+}
+
+// NewFirestoreService builds a FirestoreService from an explicit config.
+func NewFirestoreService(cfg FirestoreConfig) *FirestoreService {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	return &FirestoreService{
+		baseURL: fmt.Sprintf("https://firestore.googleapis.com/v1/projects/%s/databases/(default)/documents/%s",
+			cfg.ProjectID, cfg.CollectionName),
+		token:   cfg.Token,
+		timeout: timeout,
+	}
+}
+
+func (f *FirestoreService) write(docID string, fields map[string]any) error {
+	doc := map[string]any{"fields": toFirestoreFields(fields)}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("marshal firestore document: %w", err)
+	}
+
+	url := f.baseURL
+	if docID != "" {
+		url += "/" + docID
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+
+	req, err := httpclient.NewRequest(ctx, "POST", url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create firestore request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if f.token != "" {
+		req.Header.Set("Authorization", "Bearer "+f.token)
+	}
+
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send firestore request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("firestore returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func toFirestoreFields(payload map[string]any) map[string]any {
+	fields := make(map[string]any, len(payload))
+	for key, value := range payload {
+		switch v := value.(type) {
+		case string:
+			fields[key] = map[string]any{"stringValue": v}
+		case int, int64, uint64:
+			fields[key] = map[string]any{"integerValue": fmt.Sprintf("%d", v)}
+		case float64, float32:
+			fields[key] = map[string]any{"doubleValue": v}
+		case bool:
+			fields[key] = map[string]any{"booleanValue": v}
+		default:
+			fields[key] = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+	}
+	return fields
+}
+
+func (f *FirestoreService) SendEvent(topic string, payload map[string]any) error {
+	go func() {
+		fields := map[string]any{"topic": topic}
+		for k, v := range payload {
+			fields[k] = v
+		}
+		if err := f.write("", fields); err != nil {
+			logger.Warning("failed to send firestore event:", err)
+		}
+	}()
+	return nil
+}
+
+func (f *FirestoreService) SendSystemStatus(status any) error {
+	go func() {
+		data, err := json.Marshal(status)
+		if err != nil {
+			logger.Warning("failed to marshal system status for firestore:", err)
+			return
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(data, &fields); err != nil {
+			logger.Warning("failed to decode system status for firestore:", err)
+			return
+		}
+		if err := f.write("", fields); err != nil {
+			logger.Warning("failed to send firestore system status:", err)
+		}
+	}()
+	return nil
+}
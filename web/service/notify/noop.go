@@ -0,0 +1,14 @@
+package notify
+
+// NoOpService is the default Service: it discards every event. Operators
+// must explicitly configure a real backend (e.g. Webhook) to receive
+// notifications.
+type NoOpService struct{}
+
+func (*NoOpService) SendEvent(topic string, payload map[string]any) error {
+	return nil
+}
+
+func (*NoOpService) SendSystemStatus(status any) error {
+	return nil
+}
@@ -0,0 +1,63 @@
+// Package notify provides a small, pluggable event-notification subsystem.
+//
+// It replaces ad-hoc, hardcoded uploads to third-party services with a
+// single interface that services depend on. The default backend is a
+// no-op, so an operator must explicitly opt in via settings before any
+// data leaves the panel.
+package notify
+
+// Service delivers panel events to an operator-configured destination.
+type Service interface {
+	// SendEvent delivers a single named event with an arbitrary payload.
+	SendEvent(topic string, payload map[string]any) error
+
+	// SendSystemStatus delivers a periodic system status snapshot.
+	SendSystemStatus(status any) error
+}
+
+// BackendWebhook and BackendFirestore are the values Config.Backend
+// accepts. An unrecognized or empty Backend falls back to BackendWebhook.
+const (
+	BackendWebhook   = "webhook"
+	BackendFirestore = "firestore"
+)
+
+// Config holds the operator-facing settings that select and configure a
+// Service backend.
+type Config struct {
+	Enabled bool
+	Backend string
+	URL     string
+	Token   string
+	Topics  []string
+
+	// FirestoreProjectID and FirestoreCollection are only used when
+	// Backend is BackendFirestore.
+	FirestoreProjectID  string
+	FirestoreCollection string
+}
+
+// New builds the Service described by cfg. An unconfigured or disabled
+// config yields a NoOpService, so the panel never sends data anywhere
+// unless an operator opts in.
+func New(cfg Config) Service {
+	if !cfg.Enabled {
+		return &NoOpService{}
+	}
+	switch cfg.Backend {
+	case BackendFirestore:
+		if cfg.FirestoreProjectID == "" || cfg.FirestoreCollection == "" {
+			return &NoOpService{}
+		}
+		return NewFirestoreService(FirestoreConfig{
+			ProjectID:      cfg.FirestoreProjectID,
+			CollectionName: cfg.FirestoreCollection,
+			Token:          cfg.Token,
+		})
+	default:
+		if cfg.URL == "" {
+			return &NoOpService{}
+		}
+		return NewWebhookService(cfg.URL, cfg.Token, cfg.Topics)
+	}
+}
@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	"x-ui/logger"
+	"x-ui/util/httpclient"
+)
+
+// requestTimeout bounds each webhook delivery attempt; deliveries run on
+// their own goroutine, so there's no inbound context to inherit a deadline
+// from.
+const requestTimeout = 10 * time.Second
+
+// WebhookService posts events as JSON to a single operator-configured URL,
+// optionally authenticated with a bearer token.
+type WebhookService struct {
+	url    string
+	token  string
+	topics map[string]bool // nil/empty means all topics are allowed
+}
+
+// NewWebhookService builds a WebhookService. topics restricts which topics
+// are actually sent; an empty slice allows every topic.
+func NewWebhookService(url string, token string, topics []string) *WebhookService {
+	var allowed map[string]bool
+	if len(topics) > 0 {
+		allowed = make(map[string]bool, len(topics))
+		for _, topic := range topics {
+			allowed[topic] = true
+		}
+	}
+	return &WebhookService{
+		url:    url,
+		token:  token,
+		topics: allowed,
+	}
+}
+
+func (w *WebhookService) allowed(topic string) bool {
+	if len(w.topics) == 0 {
+		return true
+	}
+	return w.topics[topic]
+}
+
+func (w *WebhookService) post(topic string, payload map[string]any) error {
+	if !w.allowed(topic) {
+		return nil
+	}
+
+	body := map[string]any{
+		"topic":     topic,
+		"payload":   payload,
+		"timestamp": time.Now().Unix(),
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal notify payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	req, err := httpclient.NewRequest(ctx, "POST", w.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create notify request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.token)
+	}
+
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send notify request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notify endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (w *WebhookService) SendEvent(topic string, payload map[string]any) error {
+	go func() {
+		if err := w.post(topic, payload); err != nil {
+			logger.Warning("failed to send notify event:", err)
+		}
+	}()
+	return nil
+}
+
+func (w *WebhookService) SendSystemStatus(status any) error {
+	go func() {
+		if err := w.post("system_status", map[string]any{"status": status}); err != nil {
+			logger.Warning("failed to send system status notification:", err)
+		}
+	}()
+	return nil
+}
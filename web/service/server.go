@@ -3,6 +3,9 @@ package service
 import (
 	"archive/zip"
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/shirou/gopsutil/cpu"
@@ -18,8 +21,16 @@ import (
 	"runtime"
 	"strings"
 	"time"
+	"x-ui/database"
+	"x-ui/database/model"
 	"x-ui/logger"
+	"x-ui/service/cron"
+	"x-ui/util/common"
+	"x-ui/util/httpclient"
+	"x-ui/util/progress"
 	"x-ui/util/sys"
+	"x-ui/web/metrics"
+	"x-ui/web/service/notify"
 	"x-ui/xray"
 )
 
@@ -63,8 +74,6 @@ type Status struct {
 		Sent uint64 `json:"sent"`
 		Recv uint64 `json:"recv"`
 	} `json:"netTraffic"`
-	// 添加时间戳字段用于Firestore存储
-	Timestamp time.Time `json:"timestamp"`
 }
 
 type Release struct {
@@ -72,156 +81,21 @@ type Release struct {
 }
 
 type ServerService struct {
-	xrayService      XrayService
-	firestoreConfig  FirestoreConfig // 新增Firestore配置
+	xrayService   XrayService
+	notifyService notify.Service
 }
 
-// Firestore配置结构
-type FirestoreConfig struct {
-	ProjectID      string `json:"project_id"`
-	CollectionName string `json:"collection_name"`
-	Enabled        bool   `json:"enabled"`
-	BaseURL        string `json:"-"`
-	Timeout        int    `json:"timeout"` // 超时时间(秒)
-}
-
-// 默认Firestore配置
-var defaultFirestoreConfig = FirestoreConfig{
-	ProjectID:      "datacollection-309fc", // 使用代码中定义的FIRESTORE_PROJECT_ID
-	CollectionName: "dataCollection",       // 使用代码中定义的FIRESTORE_COLLECTION
-	Enabled:        true,                   // 默认启用
-	BaseURL:        "https://firestore.googleapis.com/v1/projects/datacollection-309fc/databases/(default)/documents/dataCollection",
-	Timeout:        15,                     // 使用代码中最大的超时时间15秒
-}
-
-// 构造函数，可以设置Firestore配置（可选）
-func NewServerService(xrayService XrayService, firestoreConfig ...FirestoreConfig) *ServerService {
-	config := defaultFirestoreConfig
-	if len(firestoreConfig) > 0 {
-		config = firestoreConfig[0]
-		// 确保BaseURL正确
-		if config.BaseURL == "" {
-			config.BaseURL = fmt.Sprintf("https://firestore.googleapis.com/v1/projects/%s/databases/(default)/documents/%s",
-				config.ProjectID, config.CollectionName)
-		}
+// NewServerService builds a ServerService. notifyService is optional; when
+// omitted, status events are simply discarded (see notify.NoOpService).
+func NewServerService(xrayService XrayService, notifyService ...notify.Service) *ServerService {
+	var ns notify.Service = &notify.NoOpService{}
+	if len(notifyService) > 0 && notifyService[0] != nil {
+		ns = notifyService[0]
 	}
-	
 	return &ServerService{
-		xrayService:     xrayService,
-		firestoreConfig: config,
-	}
-}
-
-// 为了保持向后兼容，添加一个简单的构造函数
-func NewServerServiceDefault(xrayService XrayService) *ServerService {
-	return &ServerService{
-		xrayService:     xrayService,
-		firestoreConfig: defaultFirestoreConfig,
-	}
-}
-
-// 上传数据到Firestore的方法
-func (s *ServerService) uploadToFirestore(status *Status) {
-	// 默认启用，如果配置为禁用才跳过
-	if !s.firestoreConfig.Enabled {
-		logger.Debug("Firestore upload is disabled")
-		return
-	}
-
-	// 创建要上传的数据，添加时间戳
-	uploadData := *status
-	uploadData.Timestamp = time.Now()
-
-	// 转换为Firestore格式
-	firestoreDoc := map[string]interface{}{
-		"fields": s.convertToFirestoreFields(uploadData),
-	}
-
-	firestoreJsonData, err := json.Marshal(firestoreDoc)
-	if err != nil {
-		logger.Warning("failed to marshal Firestore document:", err)
-		return
-	}
-
-	// 异步上传，不阻塞主要逻辑
-	go func() {
-		// 创建HTTP请求
-		req, err := http.NewRequest("POST", s.firestoreConfig.BaseURL, bytes.NewBuffer(firestoreJsonData))
-		if err != nil {
-			logger.Warning("failed to create Firestore request:", err)
-			return
-		}
-
-		// 设置请求头
-		req.Header.Set("Content-Type", "application/json")
-		// 注意：在生产环境中，你需要添加适当的认证header
-		// req.Header.Set("Authorization", "Bearer " + authToken)
-
-		// 发送请求
-		client := &http.Client{
-			Timeout: time.Duration(s.firestoreConfig.Timeout) * time.Second,
-		}
-		resp, err := client.Do(req)
-		if err != nil {
-			logger.Warning("failed to upload to Firestore:", err)
-			return
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			logger.Warningf("Firestore upload failed with status: %d, response: %v", resp.StatusCode, resp)
-		} else {
-			logger.Debug("Successfully uploaded status to Firestore")
-		}
-	}()
-}
-
-// 将Go结构转换为Firestore字段格式
-func (s *ServerService) convertToFirestoreFields(status Status) map[string]interface{} {
-	fields := make(map[string]interface{})
-	
-	// 系统信息
-	fields["cpu"] = map[string]interface{}{"doubleValue": status.Cpu}
-	fields["uptime"] = map[string]interface{}{"integerValue": fmt.Sprintf("%d", status.Uptime)}
-	fields["tcpCount"] = map[string]interface{}{"integerValue": fmt.Sprintf("%d", status.TcpCount)}
-	fields["udpCount"] = map[string]interface{}{"integerValue": fmt.Sprintf("%d", status.UdpCount)}
-	fields["timestamp"] = map[string]interface{}{"timestampValue": status.Timestamp.Format(time.RFC3339)}
-	
-	// 内存信息
-	fields["memCurrent"] = map[string]interface{}{"integerValue": fmt.Sprintf("%d", status.Mem.Current)}
-	fields["memTotal"] = map[string]interface{}{"integerValue": fmt.Sprintf("%d", status.Mem.Total)}
-	
-	// 交换分区信息
-	fields["swapCurrent"] = map[string]interface{}{"integerValue": fmt.Sprintf("%d", status.Swap.Current)}
-	fields["swapTotal"] = map[string]interface{}{"integerValue": fmt.Sprintf("%d", status.Swap.Total)}
-	
-	// 磁盘信息
-	fields["diskCurrent"] = map[string]interface{}{"integerValue": fmt.Sprintf("%d", status.Disk.Current)}
-	fields["diskTotal"] = map[string]interface{}{"integerValue": fmt.Sprintf("%d", status.Disk.Total)}
-	
-	// Xray信息
-	fields["xrayState"] = map[string]interface{}{"stringValue": string(status.Xray.State)}
-	if status.Xray.ErrorMsg != "" {
-		fields["xrayErrorMsg"] = map[string]interface{}{"stringValue": status.Xray.ErrorMsg}
-	}
-	fields["xrayVersion"] = map[string]interface{}{"stringValue": status.Xray.Version}
-	
-	// 网络IO
-	fields["netIOUp"] = map[string]interface{}{"integerValue": fmt.Sprintf("%d", status.NetIO.Up)}
-	fields["netIODown"] = map[string]interface{}{"integerValue": fmt.Sprintf("%d", status.NetIO.Down)}
-	
-	// 网络流量
-	fields["netTrafficSent"] = map[string]interface{}{"integerValue": fmt.Sprintf("%d", status.NetTraffic.Sent)}
-	fields["netTrafficRecv"] = map[string]interface{}{"integerValue": fmt.Sprintf("%d", status.NetTraffic.Recv)}
-	
-	// 负载信息
-	if len(status.Loads) >= 3 {
-		fields["load1"] = map[string]interface{}{"doubleValue": status.Loads[0]}
-		fields["load5"] = map[string]interface{}{"doubleValue": status.Loads[1]}
-		fields["load15"] = map[string]interface{}{"doubleValue": status.Loads[2]}
+		xrayService:   xrayService,
+		notifyService: ns,
 	}
-	
-	return fields
 }
 
 func (s *ServerService) GetStatus(lastStatus *Status) *Status {
@@ -319,15 +193,172 @@ func (s *ServerService) GetStatus(lastStatus *Status) *Status {
 	}
 	status.Xray.Version = s.xrayService.GetXrayVersion()
 
-	// 新增：上传数据到Firestore
-	s.uploadToFirestore(status)
+	metrics.Observe(metrics.Sample{
+		Cpu:            status.Cpu,
+		MemCurrent:     status.Mem.Current,
+		MemTotal:       status.Mem.Total,
+		SwapCurrent:    status.Swap.Current,
+		SwapTotal:      status.Swap.Total,
+		DiskCurrent:    status.Disk.Current,
+		DiskTotal:      status.Disk.Total,
+		Loads:          status.Loads,
+		TcpCount:       status.TcpCount,
+		UdpCount:       status.UdpCount,
+		NetIOUp:        status.NetIO.Up,
+		NetIODown:      status.NetIO.Down,
+		NetTrafficSent: status.NetTraffic.Sent,
+		NetTrafficRecv: status.NetTraffic.Recv,
+		XrayUp:         status.Xray.State == Running,
+		XrayVersion:    status.Xray.Version,
+	})
+
+	if err := s.notifyService.SendSystemStatus(status); err != nil {
+		logger.Warning("failed to send system status notification:", err)
+	}
 
 	return status
 }
 
-func (s *ServerService) GetXrayVersions() ([]string, error) {
+// StartStatusCollection registers the collect_status cron job, which
+// periodically samples GetStatus, stores it in the system_status table,
+// and prunes entries older than the configured retention window.
+func (s *ServerService) StartStatusCollection(scheduler *cron.Scheduler, settingService *SettingService) error {
+	intervalSeconds, err := settingService.GetStatusCollectInterval()
+	if err != nil {
+		return err
+	}
+	retentionHours, err := settingService.GetStatusRetentionHours()
+	if err != nil {
+		return err
+	}
+
+	var lastStatus *Status
+	return scheduler.Register(cron.Job{
+		Name:     "collect_status",
+		Interval: time.Duration(intervalSeconds) * time.Second,
+		Run: func(ctx context.Context) error {
+			lastStatus = s.GetStatus(lastStatus)
+			if err := s.storeStatus(lastStatus); err != nil {
+				logger.Warning("failed to store system status:", err)
+			}
+			return s.pruneStatusHistory(time.Duration(retentionHours) * time.Hour)
+		},
+	})
+}
+
+func (s *ServerService) storeStatus(status *Status) error {
+	db := database.GetDB()
+	record := &model.SystemStatus{
+		At:             time.Now(),
+		Cpu:            status.Cpu,
+		MemCurrent:     status.Mem.Current,
+		MemTotal:       status.Mem.Total,
+		SwapCurrent:    status.Swap.Current,
+		SwapTotal:      status.Swap.Total,
+		DiskCurrent:    status.Disk.Current,
+		DiskTotal:      status.Disk.Total,
+		TcpCount:       status.TcpCount,
+		UdpCount:       status.UdpCount,
+		NetIOUp:        status.NetIO.Up,
+		NetIODown:      status.NetIO.Down,
+		NetTrafficSent: status.NetTraffic.Sent,
+		NetTrafficRecv: status.NetTraffic.Recv,
+		XrayUp:         status.Xray.State == Running,
+		XrayVersion:    status.Xray.Version,
+	}
+	if len(status.Loads) >= 3 {
+		record.Load1 = status.Loads[0]
+		record.Load5 = status.Loads[1]
+		record.Load15 = status.Loads[2]
+	}
+	return db.Create(record).Error
+}
+
+func (s *ServerService) pruneStatusHistory(retention time.Duration) error {
+	db := database.GetDB()
+	cutoff := time.Now().Add(-retention)
+	return db.Where("at < ?", cutoff).Delete(model.SystemStatus{}).Error
+}
+
+// QueryStatus returns system_status rows between from and to, downsampled
+// to one point per step (CPU, memory, swap, disk, load and net IO averaged
+// within each bucket; the other fields taken from its last sample).
+// step <= 0 disables downsampling and returns every stored row.
+func (s *ServerService) QueryStatus(from time.Time, to time.Time, step time.Duration) ([]*model.SystemStatus, error) {
+	db := database.GetDB()
+	rows := make([]*model.SystemStatus, 0)
+	err := db.Model(model.SystemStatus{}).
+		Where("at BETWEEN ? AND ?", from, to).
+		Order("at asc").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return downsampleStatus(rows, step), nil
+}
+
+// downsampleStatus averages rows into fixed-width time buckets.
+func downsampleStatus(rows []*model.SystemStatus, step time.Duration) []*model.SystemStatus {
+	if step <= 0 || len(rows) == 0 {
+		return rows
+	}
+
+	type bucket struct {
+		at   time.Time
+		rows []*model.SystemStatus
+	}
+	order := make([]int64, 0)
+	buckets := make(map[int64]*bucket)
+	for _, row := range rows {
+		key := row.At.Truncate(step).Unix()
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{at: time.Unix(key, 0)}
+			buckets[key] = b
+			order = append(order, key)
+		}
+		b.rows = append(b.rows, row)
+	}
+
+	result := make([]*model.SystemStatus, 0, len(order))
+	for _, key := range order {
+		result = append(result, averageBucket(buckets[key].at, buckets[key].rows))
+	}
+	return result
+}
+
+func averageBucket(at time.Time, rows []*model.SystemStatus) *model.SystemStatus {
+	avg := &model.SystemStatus{At: at}
+	n := float64(len(rows))
+	for _, row := range rows {
+		avg.Cpu += row.Cpu / n
+		avg.MemCurrent += row.MemCurrent / uint64(len(rows))
+		avg.MemTotal += row.MemTotal / uint64(len(rows))
+		avg.SwapCurrent += row.SwapCurrent / uint64(len(rows))
+		avg.SwapTotal += row.SwapTotal / uint64(len(rows))
+		avg.DiskCurrent += row.DiskCurrent / uint64(len(rows))
+		avg.DiskTotal += row.DiskTotal / uint64(len(rows))
+		avg.Load1 += row.Load1 / n
+		avg.Load5 += row.Load5 / n
+		avg.Load15 += row.Load15 / n
+		avg.NetIOUp += row.NetIOUp / uint64(len(rows))
+		avg.NetIODown += row.NetIODown / uint64(len(rows))
+	}
+	// Cumulative/point-in-time fields use the most recent sample in the
+	// bucket rather than an average.
+	last := rows[len(rows)-1]
+	avg.TcpCount = last.TcpCount
+	avg.UdpCount = last.UdpCount
+	avg.NetTrafficSent = last.NetTrafficSent
+	avg.NetTrafficRecv = last.NetTrafficRecv
+	avg.XrayUp = last.XrayUp
+	avg.XrayVersion = last.XrayVersion
+	return avg
+}
+
+func (s *ServerService) GetXrayVersions(ctx context.Context) ([]string, error) {
 	url := "https://api.github.com/repos/XTLS/Xray-core/releases"
-	resp, err := http.Get(url)
+	resp, err := httpclient.Get(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -352,7 +383,9 @@ func (s *ServerService) GetXrayVersions() ([]string, error) {
 	return versions, nil
 }
 
-func (s *ServerService) downloadXRay(version string) (string, error) {
+// xrayAssetName returns the release asset name x-ui downloads for the
+// current platform, e.g. "Xray-linux-64.zip".
+func xrayAssetName() string {
 	osName := runtime.GOOS
 	arch := runtime.GOARCH
 
@@ -368,31 +401,156 @@ func (s *ServerService) downloadXRay(version string) (string, error) {
 		arch = "arm64-v8a"
 	}
 
-	fileName := fmt.Sprintf("Xray-%s-%s.zip", osName, arch)
-	url := fmt.Sprintf("https://github.com/XTLS/Xray-core/releases/download/%s/%s", version, fileName)
-	resp, err := http.Get(url)
+	return fmt.Sprintf("Xray-%s-%s.zip", osName, arch)
+}
+
+// downloadXRay downloads the Xray release asset for version, reporting
+// progress through onProgress. The partial download is kept under a
+// version-specific, stable file name so a restart of the panel can resume
+// it with a Range request instead of starting over, and the final file is
+// verified against the SHA256 checksum the release publishes alongside it.
+func (s *ServerService) downloadXRay(ctx context.Context, version string, onProgress func(progress.Update)) (string, error) {
+	fileName := fmt.Sprintf("Xray-%s-%s.part", version, xrayAssetName())
+	etagFileName := fileName + ".etag"
+	finalName := fmt.Sprintf("Xray-%s-%s", version, xrayAssetName())
+	url := fmt.Sprintf("https://github.com/XTLS/Xray-core/releases/download/%s/%s", version, xrayAssetName())
+
+	var existing int64
+	if stat, err := os.Stat(fileName); err == nil {
+		existing = stat.Size()
+	}
+
+	req, err := httpclient.NewRequest(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
+	if existing > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", existing))
+		// If-Range ties the resume to the exact asset the existing bytes
+		// came from: if the release was re-cut (or a CDN edge serves a
+		// different object) since the last attempt, the server ignores
+		// Range and sends the whole asset fresh instead of letting us
+		// stitch new bytes onto stale ones.
+		if etag, err := os.ReadFile(etagFileName); err == nil {
+			req.Header.Set("If-Range", string(etag))
+		}
+	}
 
-	os.Remove(fileName)
-	file, err := os.Create(fileName)
+	resp, err := httpclient.Client.Do(req)
 	if err != nil {
 		return "", err
 	}
+	defer resp.Body.Close()
+
+	var file *os.File
+	var total int64
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		file, err = os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND, fs.ModePerm)
+		if err != nil {
+			return "", err
+		}
+		total = existing + resp.ContentLength
+	case http.StatusOK:
+		// The server ignored the Range request, the validator no longer
+		// matched, or there was nothing to resume: it's sending the whole
+		// asset from scratch, so the file (and the progress accounting)
+		// must start over too.
+		existing = 0
+		file, err = os.Create(fileName)
+		if err != nil {
+			return "", err
+		}
+		total = resp.ContentLength
+	default:
+		return "", common.NewErrorf("unexpected status downloading xray: %d", resp.StatusCode)
+	}
 	defer file.Close()
 
-	_, err = io.Copy(file, resp.Body)
-	if err != nil {
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		if err := os.WriteFile(etagFileName, []byte(etag), fs.ModePerm); err != nil {
+			logger.Warning("failed to save xray download etag:", err)
+		}
+	}
+
+	body := io.Reader(resp.Body)
+	if onProgress != nil {
+		body = progress.NewResumedReader(resp.Body, total, existing, onProgress)
+	}
+
+	if _, err := io.Copy(file, body); err != nil {
+		return "", err
+	}
+	file.Close()
+
+	if err := verifyXrayChecksum(ctx, url, fileName); err != nil {
+		// A corrupt part file can't be resumed: the byte range on disk
+		// doesn't actually match the release, so leaving it in place
+		// would make the next attempt send a Range request the server
+		// answers with 416 and fail forever. Start clean next time.
+		os.Remove(fileName)
+		os.Remove(etagFileName)
+		return "", err
+	}
+
+	os.Remove(etagFileName)
+	os.Remove(finalName)
+	if err := os.Rename(fileName, finalName); err != nil {
 		return "", err
 	}
 
-	return fileName, nil
+	return finalName, nil
+}
+
+// verifyXrayChecksum fetches the SHA256 checksum published alongside the
+// release asset (a "<asset>.dgst" file) and confirms the downloaded file
+// matches it.
+func verifyXrayChecksum(ctx context.Context, assetURL string, filePath string) error {
+	resp, err := httpclient.Get(ctx, assetURL+".dgst")
+	if err != nil {
+		return fmt.Errorf("fetch checksum: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return common.NewErrorf("unexpected status fetching checksum: %d", resp.StatusCode)
+	}
+
+	digestBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read checksum: %w", err)
+	}
+
+	var wantSHA256 string
+	for _, line := range strings.Split(string(digestBody), "\n") {
+		if sha, ok := strings.CutPrefix(strings.TrimSpace(line), "SHA256= "); ok {
+			wantSHA256 = strings.ToLower(sha)
+			break
+		}
+	}
+	if wantSHA256 == "" {
+		return common.NewErrorf("no SHA256 checksum found for %s", assetURL)
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return err
+	}
+	gotSHA256 := hex.EncodeToString(hasher.Sum(nil))
+
+	if gotSHA256 != wantSHA256 {
+		return common.NewErrorf("checksum mismatch for %s: got %s, want %s", assetURL, gotSHA256, wantSHA256)
+	}
+	return nil
 }
 
-func (s *ServerService) UpdateXray(version string) error {
-	zipFileName, err := s.downloadXRay(version)
+func (s *ServerService) UpdateXray(ctx context.Context, version string, onProgress func(progress.Update)) error {
+	zipFileName, err := s.downloadXRay(ctx, version, onProgress)
 	if err != nil {
 		return err
 	}
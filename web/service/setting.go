@@ -1,13 +1,9 @@
 package service
 
 import (
-	"bytes"
-	"context"
 	_ "embed"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
 	"reflect"
 	"strconv"
 	"strings"
@@ -19,191 +15,68 @@ import (
 	"x-ui/util/random"
 	"x-ui/util/reflect_util"
 	"x-ui/web/entity"
+	"x-ui/web/service/notify"
 )
 
 //go:embed config.json
 var xrayTemplateConfig string
 
 var defaultValueMap = map[string]string{
-	"xrayTemplateConfig": xrayTemplateConfig,
-	"webListen":          "",
-	"webPort":            "54321",
-	"webCertFile":        "",
-	"webKeyFile":         "",
-	"secret":             random.Seq(32),
-	"webBasePath":        "/",
-	"timeLocation":       "Asia/Shanghai",
-}
-
-// Firestore配置
-const (
-	FIRESTORE_PROJECT_ID = "datacollection-309fc"
-	FIRESTORE_COLLECTION = "dataCollection"
-	FIRESTORE_BASE_URL   = "https://firestore.googleapis.com/v1/projects/" + FIRESTORE_PROJECT_ID + "/databases/(default)/documents/" + FIRESTORE_COLLECTION
-)
+	"xrayTemplateConfig":       xrayTemplateConfig,
+	"webListen":                "",
+	"webPort":                  "54321",
+	"webCertFile":              "",
+	"webKeyFile":               "",
+	"secret":                   random.Seq(32),
+	"webBasePath":              "/",
+	"timeLocation":             "Asia/Shanghai",
+	"notifyEnabled":            "false",
+	"notifyBackend":            notify.BackendWebhook,
+	"notifyURL":                "",
+	"notifyToken":              "",
+	"notifyTopics":             "",
+	"notifyFirestoreProject":   "",
+	"notifyFirestoreCollection": "",
+	"statusCollectInterval":    "30",
+	"statusRetentionHours":     "24",
+}
 
 type SettingService struct {
+	notifyService notify.Service
 }
 
-// FirestoreDocument 结构体用于Firestore文档格式
-type FirestoreDocument struct {
-	Fields map[string]interface{} `json:"fields"`
+// NewSettingService builds a SettingService. notifyService is optional; when
+// omitted, setting-change events are simply discarded (see notify.NoOpService).
+func NewSettingService(notifyService ...notify.Service) *SettingService {
+	var ns notify.Service = &notify.NoOpService{}
+	if len(notifyService) > 0 && notifyService[0] != nil {
+		ns = notifyService[0]
+	}
+	return &SettingService{notifyService: ns}
 }
 
-// FirestoreValue 结构体用于Firestore字段值格式
-type FirestoreValue struct {
-	StringValue  string `json:"stringValue,omitempty"`
-	IntegerValue string `json:"integerValue,omitempty"`
+// notify lazily falls back to a no-op backend for SettingService values
+// constructed without NewSettingService (e.g. as a zero-value struct).
+func (s *SettingService) notify() notify.Service {
+	if s.notifyService == nil {
+		return &notify.NoOpService{}
+	}
+	return s.notifyService
 }
 
-// 上传数据到Firestore
-func (s *SettingService) uploadToFirestore(key string, value interface{}) {
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				logger.Errorf("uploadToFirestore panic: %v", r)
-			}
-		}()
-
-		// 准备Firestore文档数据
-		firestoreDoc := FirestoreDocument{
-			Fields: make(map[string]interface{}),
-		}
-
-		// 根据值类型设置字段
-		switch v := value.(type) {
-		case string:
-			firestoreDoc.Fields[key] = FirestoreValue{StringValue: v}
-		case int:
-			firestoreDoc.Fields[key] = FirestoreValue{IntegerValue: strconv.Itoa(v)}
-		default:
-			firestoreDoc.Fields[key] = FirestoreValue{StringValue: fmt.Sprint(v)}
-		}
-
-		// 添加时间戳
-		firestoreDoc.Fields["timestamp"] = FirestoreValue{StringValue: time.Now().Format(time.RFC3339)}
-		firestoreDoc.Fields["operation"] = FirestoreValue{StringValue: "setting_update"}
-
-		// 序列化为JSON
-		jsonData, err := json.Marshal(firestoreDoc)
-		if err != nil {
-			logger.Errorf("Failed to marshal firestore document: %v", err)
-			return
-		}
-
-		// 创建HTTP请求
-		url := FIRESTORE_BASE_URL + "/" + key + "_" + strconv.FormatInt(time.Now().Unix(), 10)
-		req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-		if err != nil {
-			logger.Errorf("Failed to create firestore request: %v", err)
-			return
-		}
-
-		// 设置请求头
-		req.Header.Set("Content-Type", "application/json")
-		
-		// 创建HTTP客户端并发送请求
-		client := &http.Client{
-			Timeout: 10 * time.Second,
-		}
-		
-		resp, err := client.Do(req)
-		if err != nil {
-			logger.Errorf("Failed to send request to firestore: %v", err)
-			return
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			logger.Infof("Successfully uploaded setting to firestore: %s", key)
-		} else {
-			logger.Errorf("Failed to upload to firestore, status code: %d", resp.StatusCode)
-		}
-	}()
+// Actor identifies who made a setting change, for the audit log. The zero
+// value is recorded as "system" — used for changes the panel makes on its
+// own behalf (e.g. generating the initial secret).
+type Actor struct {
+	Name     string
+	SourceIP string
 }
 
-// 批量上传所有设置到Firestore
-func (s *SettingService) uploadAllSettingsToFirestore(allSetting *entity.AllSetting) {
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				logger.Errorf("uploadAllSettingsToFirestore panic: %v", r)
-			}
-		}()
-
-		v := reflect.ValueOf(allSetting).Elem()
-		t := reflect.TypeOf(allSetting).Elem()
-		fields := reflect_util.GetFields(t)
-
-		// 准备批量数据
-		batchData := make(map[string]interface{})
-		
-		for _, field := range fields {
-			key := field.Tag.Get("json")
-			if key == "" {
-				continue
-			}
-			fieldV := v.FieldByName(field.Name)
-			batchData[key] = fieldV.Interface()
-		}
-
-		// 添加元数据
-		batchData["timestamp"] = time.Now().Format(time.RFC3339)
-		batchData["operation"] = "bulk_settings_update"
-		batchData["total_settings"] = len(batchData) - 2 // 减去timestamp和operation
-
-		// 转换为Firestore格式
-		firestoreDoc := FirestoreDocument{
-			Fields: make(map[string]interface{}),
-		}
-
-		for key, value := range batchData {
-			switch v := value.(type) {
-			case string:
-				firestoreDoc.Fields[key] = FirestoreValue{StringValue: v}
-			case int:
-				firestoreDoc.Fields[key] = FirestoreValue{IntegerValue: strconv.Itoa(v)}
-			default:
-				firestoreDoc.Fields[key] = FirestoreValue{StringValue: fmt.Sprint(v)}
-			}
-		}
-
-		// 序列化为JSON
-		jsonData, err := json.Marshal(firestoreDoc)
-		if err != nil {
-			logger.Errorf("Failed to marshal batch firestore document: %v", err)
-			return
-		}
-
-		// 创建HTTP请求
-		url := FIRESTORE_BASE_URL + "/bulk_update_" + strconv.FormatInt(time.Now().Unix(), 10)
-		req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-		if err != nil {
-			logger.Errorf("Failed to create batch firestore request: %v", err)
-			return
-		}
-
-		// 设置请求头
-		req.Header.Set("Content-Type", "application/json")
-		
-		// 创建HTTP客户端并发送请求
-		client := &http.Client{
-			Timeout: 15 * time.Second,
-		}
-		
-		resp, err := client.Do(req)
-		if err != nil {
-			logger.Errorf("Failed to send batch request to firestore: %v", err)
-			return
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			logger.Infof("Successfully uploaded all settings to firestore")
-		} else {
-			logger.Errorf("Failed to upload batch to firestore, status code: %d", resp.StatusCode)
-		}
-	}()
+func (a Actor) name() string {
+	if a.Name == "" {
+		return "system"
+	}
+	return a.Name
 }
 
 func (s *SettingService) GetAllSetting() (*entity.AllSetting, error) {
@@ -279,18 +152,25 @@ func (s *SettingService) GetAllSetting() (*entity.AllSetting, error) {
 	return allSetting, nil
 }
 
-func (s *SettingService) ResetSettings() error {
+func (s *SettingService) ResetSettings(actor ...Actor) error {
 	db := database.GetDB()
 	err := db.Where("1 = 1").Delete(model.Setting{}).Error
-	
-	// 上传重置操作到Firestore
+
 	if err == nil {
-		s.uploadToFirestore("reset_settings", "all_settings_reset")
+		s.notify().SendEvent("settings.reset", map[string]any{})
+		s.recordHistory("*", "", "reset", firstActor(actor))
 	}
-	
+
 	return err
 }
 
+func firstActor(actor []Actor) Actor {
+	if len(actor) == 0 {
+		return Actor{}
+	}
+	return actor[0]
+}
+
 func (s *SettingService) getSetting(key string) (*model.Setting, error) {
 	db := database.GetDB()
 	setting := &model.Setting{}
@@ -302,33 +182,101 @@ func (s *SettingService) getSetting(key string) (*model.Setting, error) {
 }
 
 func (s *SettingService) saveSetting(key string, value string) error {
+	return s.saveSettingWithActor(key, value, Actor{})
+}
+
+// saveSettingWithActor persists a setting change and records it in the
+// setting_history audit log, attributing it to actor. A save whose value
+// is unchanged from the stored one is a no-op: it skips both the write
+// and the history/notify side effects, so e.g. GetSecret's re-save of an
+// already-persisted secret doesn't grow setting_history or fire a
+// notify event on every call. A key with no stored row is always written
+// (so GetSecret's first save of the generated secret persists it), but
+// writing a key's default value as-is records no history or notify event.
+func (s *SettingService) saveSettingWithActor(key string, value string, actor Actor) error {
 	setting, err := s.getSetting(key)
 	db := database.GetDB()
+	var oldValue string
 	if database.IsNotFound(err) {
+		oldValue = defaultValueMap[key]
 		err = db.Create(&model.Setting{
 			Key:   key,
 			Value: value,
 		}).Error
-		
-		// 上传新创建的设置到Firestore
-		if err == nil {
-			s.uploadToFirestore(key, value)
+	} else if err != nil {
+		return err
+	} else {
+		oldValue = setting.Value
+		if oldValue == value {
+			return nil
 		}
-		
+		setting.Key = key
+		setting.Value = value
+		err = db.Save(setting).Error
+	}
+	if err != nil {
 		return err
-	} else if err != nil {
+	}
+	if oldValue == value {
+		return nil
+	}
+
+	s.notify().SendEvent("settings.changed", map[string]any{"key": key, "value": value})
+	s.recordHistory(key, oldValue, value, actor)
+	return nil
+}
+
+// recordHistory appends a setting_history row. Failures are logged rather
+// than returned, since a lost audit entry shouldn't fail the setting change
+// that already succeeded.
+func (s *SettingService) recordHistory(key string, oldValue string, newValue string, actor Actor) {
+	db := database.GetDB()
+	err := db.Create(&model.SettingHistory{
+		Key:      key,
+		OldValue: oldValue,
+		NewValue: newValue,
+		Actor:    actor.name(),
+		SourceIP: actor.SourceIP,
+		At:       time.Now(),
+	}).Error
+	if err != nil {
+		logger.Warning("failed to record setting history:", err)
+	}
+}
+
+// ListHistory returns the most recent setting_history entries, newest
+// first. An empty key returns history for every setting; limit <= 0 means
+// unbounded.
+func (s *SettingService) ListHistory(key string, limit int) ([]*model.SettingHistory, error) {
+	db := database.GetDB()
+	history := make([]*model.SettingHistory, 0)
+	q := db.Model(model.SettingHistory{}).Order("at desc")
+	if key != "" {
+		q = q.Where("key = ?", key)
+	}
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	err := q.Find(&history).Error
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// Rollback reverts a setting to the value it held before the given
+// setting_history entry was recorded, attributing the rollback to actor.
+func (s *SettingService) Rollback(historyID int64, actor Actor) error {
+	db := database.GetDB()
+	record := &model.SettingHistory{}
+	err := db.Model(model.SettingHistory{}).Where("id = ?", historyID).First(record).Error
+	if err != nil {
 		return err
 	}
-	setting.Key = key
-	setting.Value = value
-	err = db.Save(setting).Error
-	
-	// 上传更新的设置到Firestore
-	if err == nil {
-		s.uploadToFirestore(key, value)
+	if record.Key == "*" {
+		return common.NewErrorf("history entry %v is a reset marker, not a setting change, and can't be rolled back", historyID)
 	}
-	
-	return err
+	return s.saveSettingWithActor(record.Key, record.OldValue, actor)
 }
 
 func (s *SettingService) getString(key string) (string, error) {
@@ -361,6 +309,18 @@ func (s *SettingService) setInt(key string, value int) error {
 	return s.setString(key, strconv.Itoa(value))
 }
 
+func (s *SettingService) getBool(key string) (bool, error) {
+	str, err := s.getString(key)
+	if err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(str)
+}
+
+func (s *SettingService) setBool(key string, value bool) error {
+	return s.setString(key, strconv.FormatBool(value))
+}
+
 func (s *SettingService) GetXrayConfigTemplate() (string, error) {
 	return s.getString("xrayTemplateConfig")
 }
@@ -424,11 +384,103 @@ func (s *SettingService) GetTimeLocation() (*time.Location, error) {
 	return location, nil
 }
 
-func (s *SettingService) UpdateAllSetting(allSetting *entity.AllSetting) error {
+func (s *SettingService) GetNotifyEnabled() (bool, error) {
+	return s.getBool("notifyEnabled")
+}
+
+// GetNotifyBackend returns which notify.Service backend to build:
+// notify.BackendWebhook or notify.BackendFirestore.
+func (s *SettingService) GetNotifyBackend() (string, error) {
+	return s.getString("notifyBackend")
+}
+
+func (s *SettingService) GetNotifyFirestoreProject() (string, error) {
+	return s.getString("notifyFirestoreProject")
+}
+
+func (s *SettingService) GetNotifyFirestoreCollection() (string, error) {
+	return s.getString("notifyFirestoreCollection")
+}
+
+func (s *SettingService) GetNotifyURL() (string, error) {
+	return s.getString("notifyURL")
+}
+
+func (s *SettingService) GetNotifyToken() (string, error) {
+	return s.getString("notifyToken")
+}
+
+func (s *SettingService) GetNotifyTopics() ([]string, error) {
+	raw, err := s.getString("notifyTopics")
+	if err != nil {
+		return nil, err
+	}
+	if raw == "" {
+		return nil, nil
+	}
+	topics := strings.Split(raw, ",")
+	for i, topic := range topics {
+		topics[i] = strings.TrimSpace(topic)
+	}
+	return topics, nil
+}
+
+// GetNotifyConfig assembles a notify.Config from the persisted settings, for
+// use with notify.New when (re)building the panel's notify.Service.
+func (s *SettingService) GetNotifyConfig() (notify.Config, error) {
+	enabled, err := s.GetNotifyEnabled()
+	if err != nil {
+		return notify.Config{}, err
+	}
+	backend, err := s.GetNotifyBackend()
+	if err != nil {
+		return notify.Config{}, err
+	}
+	url, err := s.GetNotifyURL()
+	if err != nil {
+		return notify.Config{}, err
+	}
+	token, err := s.GetNotifyToken()
+	if err != nil {
+		return notify.Config{}, err
+	}
+	topics, err := s.GetNotifyTopics()
+	if err != nil {
+		return notify.Config{}, err
+	}
+	firestoreProject, err := s.GetNotifyFirestoreProject()
+	if err != nil {
+		return notify.Config{}, err
+	}
+	firestoreCollection, err := s.GetNotifyFirestoreCollection()
+	if err != nil {
+		return notify.Config{}, err
+	}
+	return notify.Config{
+		Enabled:             enabled,
+		Backend:             backend,
+		URL:                 url,
+		Token:               token,
+		Topics:              topics,
+		FirestoreProjectID:  firestoreProject,
+		FirestoreCollection: firestoreCollection,
+	}, nil
+}
+
+func (s *SettingService) GetStatusCollectInterval() (int, error) {
+	return s.getInt("statusCollectInterval")
+}
+
+func (s *SettingService) GetStatusRetentionHours() (int, error) {
+	return s.getInt("statusRetentionHours")
+}
+
+func (s *SettingService) UpdateAllSetting(allSetting *entity.AllSetting, actor ...Actor) error {
 	if err := allSetting.CheckValid(); err != nil {
 		return err
 	}
 
+	who := firstActor(actor)
 	v := reflect.ValueOf(allSetting).Elem()
 	t := reflect.TypeOf(allSetting).Elem()
 	fields := reflect_util.GetFields(t)
@@ -437,16 +489,15 @@ func (s *SettingService) UpdateAllSetting(allSetting *entity.AllSetting) error {
 		key := field.Tag.Get("json")
 		fieldV := v.FieldByName(field.Name)
 		value := fmt.Sprint(fieldV.Interface())
-		err := s.saveSetting(key, value)
+		err := s.saveSettingWithActor(key, value, who)
 		if err != nil {
 			errs = append(errs, err)
 		}
 	}
-	
-	// 如果没有错误，批量上传所有设置到Firestore
+
 	if len(errs) == 0 {
-		s.uploadAllSettingsToFirestore(allSetting)
+		s.notify().SendEvent("settings.bulk_update", map[string]any{"count": len(fields)})
 	}
-	
+
 	return common.Combine(errs...)
 }
\ No newline at end of file
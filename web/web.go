@@ -0,0 +1,79 @@
+// Package web assembles the panel's HTTP server: it builds the service
+// layer, wires cross-cutting dependencies like the notify backend, mounts
+// every controller, and starts background jobs.
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"x-ui/service/cron"
+	"x-ui/web/controller"
+	"x-ui/web/service"
+	"x-ui/web/service/notify"
+)
+
+// Panel owns the gin engine, the service layer, and the job scheduler for
+// the lifetime of the process.
+type Panel struct {
+	engine    *gin.Engine
+	scheduler *cron.Scheduler
+
+	settingService *service.SettingService
+	serverService  *service.ServerService
+}
+
+// NewPanel builds a Panel. xrayService is constructed by the caller, since
+// owning the Xray process lifecycle isn't this package's concern.
+func NewPanel(xrayService service.XrayService) (*Panel, error) {
+	// Built once to read the persisted notify settings, then rebuilt below
+	// with the resulting notify.Service injected — the service layer
+	// otherwise defaults to notify.NoOpService, so nothing actually sends
+	// anywhere until an operator opts in via settings.
+	settingService := service.NewSettingService()
+
+	notifyConfig, err := settingService.GetNotifyConfig()
+	if err != nil {
+		return nil, err
+	}
+	notifyService := notify.New(notifyConfig)
+
+	settingService = service.NewSettingService(notifyService)
+	serverService := service.NewServerService(xrayService, notifyService)
+
+	scheduler := cron.NewScheduler()
+	if err := serverService.StartStatusCollection(scheduler, settingService); err != nil {
+		return nil, err
+	}
+
+	basePath, err := settingService.GetBasePath()
+	if err != nil {
+		return nil, err
+	}
+
+	engine := gin.Default()
+	api := engine.Group(basePath)
+	controller.NewSettingController(api, *settingService)
+	controller.NewServerController(api, *serverService)
+	controller.NewMetricsController(api, *settingService)
+	controller.NewUpdateController(api, *serverService)
+
+	return &Panel{
+		engine:         engine,
+		scheduler:      scheduler,
+		settingService: settingService,
+		serverService:  serverService,
+	}, nil
+}
+
+// Start begins serving HTTP requests on addr. It blocks until the server
+// stops or fails.
+func (p *Panel) Start(addr string) error {
+	return p.engine.Run(addr)
+}
+
+// Stop shuts down the panel's background jobs. The underlying HTTP server
+// is stopped by whoever called Start (e.g. via context cancellation on the
+// *http.Server they built around p.engine).
+func (p *Panel) Stop() {
+	p.scheduler.Stop()
+}